@@ -2,10 +2,13 @@ package cc_validator_api
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/tarm/serial"
@@ -86,45 +89,187 @@ type Bill struct {
 	CountryCode  string
 }
 
+// Port is the minimal transport a CCValidator speaks over. *serial.Port
+// satisfies it directly; tests and the simulator substitute an in-memory
+// io.ReadWriteCloser (e.g. net.Pipe) so the protocol logic can be exercised
+// without real hardware.
+type Port interface {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
 type CCValidator struct {
-	config  *serial.Config
-	port    *serial.Port
-	logging bool
-	open    bool
+	port   Port
+	name   string
+	logger Logger
+	open   bool
+
+	// cfg holds the last known SetSecurity/EnableBillTypes arguments behind a
+	// pointer so every copy of a CCValidator (it is handed around by value)
+	// and the reconnect supervisor installed as its Port share the same
+	// record, letting the supervisor replay it after a reconnect.
+	cfg *lastConfig
+
+	// state tracks the last status Poll observed, behind a pointer for the
+	// same reason as cfg, so the Info/Warn/Error logging in logStatusChange
+	// only fires on an actual transition.
+	state *pollState
+
+	// reads serializes every readFull against port behind a pointer for the
+	// same reason as cfg and state, so a read abandoned by one command's
+	// context deadline never runs concurrently with the next command's read
+	// of the same connection; see readFull.
+	reads *serialReadQueue
+
+	// dispatch serializes every request/response round trip issued through
+	// this CCValidator, behind a pointer for the same reason as cfg, state
+	// and reads. supervisedPort.recover holds it across its entire replay
+	// sequence after a reconnect, so an ordinary command's Write can never
+	// land on the wire in the middle of one of recover's frames.
+	dispatch *sync.Mutex
+
+	pollInterval time.Duration
+	readTimeout  time.Duration
+}
+
+type pollState struct {
+	mu   sync.Mutex
+	last Status
+	have bool
 }
 
-func NewConnection(path string, baud Baud, logging bool) (CCValidator, error) {
-	c := &serial.Config{Name: path, Baud: int(baud), ReadTimeout: 5 * time.Second} // TODO
-	o, err := serial.OpenPort(c)
+// NewConnection opens a serial connection to path, configured by opts (see
+// WithBaud, WithParity, WithStopBits, WithDataBits, WithReadTimeout,
+// WithPollInterval and WithLogger). Unset options default to 9600 8N1 with a
+// 5s read timeout and no logging. The returned CCValidator transparently
+// reconnects, with exponential backoff, on a read or write error.
+func NewConnection(path string, opts ...Option) (CCValidator, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &serial.Config{
+		Name:        path,
+		Baud:        int(o.baud),
+		Parity:      o.parity,
+		StopBits:    o.stopBits,
+		Size:        byte(o.dataBits),
+		ReadTimeout: o.readTimeout,
+	}
+
+	res := CCValidator{name: path, logger: o.logger, cfg: &lastConfig{}, state: &pollState{}, reads: &serialReadQueue{}, dispatch: &sync.Mutex{}, pollInterval: o.pollInterval, readTimeout: o.readTimeout}
 
-	res := CCValidator{}
+	port, err := newSupervisedPort(context.Background(), SerialTransport{Config: c}, &res)
 
 	if err != nil {
-		return res, err
+		return CCValidator{}, err
 	}
 
-	res.config = c
-	res.port = o
-	res.logging = logging
+	res.port = port
 	res.open = true
 
 	return res, nil
 }
 
+// NewConnectionClassic is the pre-Option constructor, kept so existing
+// callers compile.
+//
+// Deprecated: use NewConnection(path, opts...) instead, e.g.
+// NewConnection(path, WithBaud(baud), WithLogger(logger)) if logging is
+// true.
+func NewConnectionClassic(path string, baud Baud, logging bool) (CCValidator, error) {
+	return NewConnection(path, WithBaud(baud), WithLogger(loggerFor(logging)))
+}
+
+// NewConnectionWithPort wraps an already-open Port as a CCValidator. It is
+// meant for tests, where the serial port is replaced by a plain
+// io.ReadWriteCloser with no reconnect behaviour; real callers should use
+// NewConnection or NewConnectionWithTransport instead.
+func NewConnectionWithPort(port Port, logging bool) CCValidator {
+	return CCValidator{port: port, name: "custom", logger: loggerFor(logging), open: true, cfg: &lastConfig{}, state: &pollState{}, reads: &serialReadQueue{}, dispatch: &sync.Mutex{}}
+}
+
+// NewConnectionWithTransport opens transport and wraps the resulting
+// connection in a supervisor that transparently reconnects (with exponential
+// backoff) and replays the validator's configuration after a read/write
+// error, so a USB re-enumeration or a cable glitch doesn't take down a
+// long-running process. name is used only for log lines.
+func NewConnectionWithTransport(ctx context.Context, transport Transport, name string, logging bool) (CCValidator, error) {
+	res := CCValidator{name: name, logger: loggerFor(logging), cfg: &lastConfig{}, state: &pollState{}, reads: &serialReadQueue{}, dispatch: &sync.Mutex{}}
+
+	port, err := newSupervisedPort(ctx, transport, &res)
+
+	if err != nil {
+		return CCValidator{}, err
+	}
+
+	res.port = port
+	res.open = true
+
+	return res, nil
+}
+
+// PollInterval returns the poll cadence configured via WithPollInterval (or
+// the package default of 150ms), for callers such as NewSession that would
+// otherwise have to hardcode one.
+func (s *CCValidator) PollInterval() time.Duration {
+	if s.pollInterval <= 0 {
+		return defaultPollInterval
+	}
+	return s.pollInterval
+}
+
+// ReadTimeout returns the read timeout configured via WithReadTimeout (or
+// the package default of 5s). The plain, non-Context wrapper methods below
+// use it as their deadline, so a validator that never answers returns an
+// error instead of hanging (or hot-spinning; see readFullBounded) forever.
+func (s *CCValidator) ReadTimeout() time.Duration {
+	if s.readTimeout <= 0 {
+		return defaultReadTimeout
+	}
+	return s.readTimeout
+}
+
+// backgroundContext returns a context.Background bounded by ReadTimeout,
+// for the plain wrapper methods to pass to their *Context sibling.
+func (s *CCValidator) backgroundContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.ReadTimeout())
+}
+
+// OnReconnect registers fn to be called (from whatever goroutine triggers
+// the reconnect) after the underlying transport has been reopened and the
+// validator's configuration replayed. It has no effect unless this
+// CCValidator was constructed with NewConnectionWithTransport or
+// NewConnection, which uses one internally.
+func (s *CCValidator) OnReconnect(fn func()) {
+	if sp, ok := s.port.(*supervisedPort); ok {
+		sp.setOnReconnect(fn)
+	}
+}
+
+// Open reopens a connection previously closed with Close. It only supports
+// CCValidators constructed with NewConnection or NewConnectionWithTransport,
+// whose Port is the reconnect supervisor installed by that constructor;
+// others (e.g. NewConnectionWithPort, used by tests and the simulator) have
+// no transport to reopen.
 func (s *CCValidator) Open() error {
 	if s.open {
 		return errors.New("port already opened")
 	}
 
-	p, err := serial.OpenPort(s.config)
+	sp, ok := s.port.(*supervisedPort)
 
-	if err != nil {
+	if !ok {
+		return errors.New("connection does not support reopening")
+	}
+
+	if err := sp.tryOpen(context.Background()); err != nil {
 		return err
 	}
 
-	s.port = p
 	s.open = true
-
 	return nil
 }
 
@@ -139,25 +284,41 @@ func (s *CCValidator) Close() error {
 	return err
 }
 
-func (s *CCValidator) Reset() error {
-	err := sendRequest(s, 0x30, []byte{})
+func (s *CCValidator) ResetContext(ctx context.Context) error {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
 
-	if err != nil {
+	return s.resetLocked(ctx)
+}
+
+// resetLocked is ResetContext's body, called directly by recover (which
+// already holds s.dispatch for its whole replay sequence) so it doesn't
+// re-lock a mutex it's already holding.
+func (s *CCValidator) resetLocked(ctx context.Context) error {
+	if err := sendRequest(ctx, s, 0x30, []byte{}); err != nil {
 		return err
 	}
 
-	_, err = readResponse(s)
+	_, err := readResponse(ctx, s)
 	return err
 }
 
-func (s *CCValidator) GetStatus() ([]uint, []uint, error) {
-	err := sendRequest(s, 0x31, []byte{})
+func (s *CCValidator) Reset() error {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.ResetContext(ctx)
+}
+
+func (s *CCValidator) GetStatusContext(ctx context.Context) ([]uint, []uint, error) {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	if err := sendRequest(ctx, s, 0x31, []byte{}); err != nil {
 		return nil, nil, err
 	}
 
-	response, err := readResponse(s)
+	response, err := readResponse(ctx, s)
 
 	if err != nil {
 		return nil, nil, err
@@ -187,7 +348,23 @@ func (s *CCValidator) GetStatus() ([]uint, []uint, error) {
 	return enabledBills, securityBills, nil
 }
 
-func (s *CCValidator) SetSecurity(security []byte) error {
+func (s *CCValidator) GetStatus() ([]uint, []uint, error) {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
+
+	return s.GetStatusContext(ctx)
+}
+
+func (s *CCValidator) SetSecurityContext(ctx context.Context, security []byte) error {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	return s.setSecurityLocked(ctx, security)
+}
+
+// setSecurityLocked is SetSecurityContext's body, called directly by
+// recover (see resetLocked) so it doesn't re-lock s.dispatch.
+func (s *CCValidator) setSecurityLocked(ctx context.Context, security []byte) error {
 	securityBytes := []byte{0, 0, 0}
 
 	for _, t := range security {
@@ -195,24 +372,36 @@ func (s *CCValidator) SetSecurity(security []byte) error {
 		securityBytes[pos/8] |= 1 << (7 - pos + pos/8*8)
 	}
 
-	err := sendRequest(s, 0x32, securityBytes)
+	if err := sendRequest(ctx, s, 0x32, securityBytes); err != nil {
+		return err
+	}
 
-	if err != nil {
+	if _, err := readResponse(ctx, s); err != nil {
 		return err
 	}
 
-	_, err = readResponse(s)
-	return err
+	if s.cfg != nil {
+		s.cfg.setSecurity(security)
+	}
+	return nil
 }
 
-func (s *CCValidator) Poll() (Status, byte, error) {
-	err := sendRequest(s, 0x33, []byte{})
+func (s *CCValidator) SetSecurity(security []byte) error {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.SetSecurityContext(ctx, security)
+}
+
+func (s *CCValidator) PollContext(ctx context.Context) (Status, byte, error) {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	if err := sendRequest(ctx, s, 0x33, []byte{}); err != nil {
 		return 0, 0, err
 	}
 
-	response, err := readResponse(s)
+	response, err := readResponse(ctx, s)
 
 	if err != nil {
 		return 0, 0, err
@@ -223,17 +412,75 @@ func (s *CCValidator) Poll() (Status, byte, error) {
 		param = response[1]
 	}
 
-	return Status(response[0]), param, err
+	status := Status(response[0])
+	s.logStatusChange(status, param)
+
+	return status, param, err
 }
 
-func (s *CCValidator) Identification() (Identification, error) {
-	err := sendRequest(s, 0x37, []byte{})
+func (s *CCValidator) Poll() (Status, byte, error) {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.PollContext(ctx)
+}
+
+// logStatusChange emits an Info/Warn/Error log line the first time Poll
+// observes status, so a caller with WithLogger set gets PowerUp->Idling,
+// escrow, stack and failure transitions without having to diff statuses
+// itself (see also Session, which does the same diffing to publish Events).
+func (s *CCValidator) logStatusChange(status Status, param byte) {
+	if s.state == nil {
+		return
+	}
+
+	s.state.mu.Lock()
+	prev, have := s.state.last, s.state.have
+	s.state.last = status
+	s.state.have = true
+	s.state.mu.Unlock()
+
+	if have && prev == status {
+		return
+	}
+
+	switch status {
+	case Idling:
+		if have && prev == PowerUp {
+			s.logger.Infof("validator[%v]: ready (Idling)", s.name)
+		}
+	case EscrowPosition:
+		s.logger.Infof("validator[%v]: bill in escrow (type %d)", s.name, param)
+	case Stacking:
+		s.logger.Infof("validator[%v]: stacking bill", s.name)
+	case BillStacked:
+		s.logger.Infof("validator[%v]: bill stacked", s.name)
+	case Returning:
+		s.logger.Infof("validator[%v]: returning bill", s.name)
+	case BillReturned:
+		s.logger.Infof("validator[%v]: bill returned", s.name)
+	case Rejecting:
+		s.logger.Warnf("validator[%v]: bill rejected (code %X)", s.name, param)
+	case GenericFailure, ValidatorJammed, DropCassetteJammed, Cheated, DropCassetteOutOfPosition:
+		s.logger.Errorf("validator[%v]: failure (status %X)", s.name, status)
+	}
+}
+
+func (s *CCValidator) IdentificationContext(ctx context.Context) (Identification, error) {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	return s.identificationLocked(ctx)
+}
+
+// identificationLocked is IdentificationContext's body, called directly by
+// recover (see resetLocked) so it doesn't re-lock s.dispatch.
+func (s *CCValidator) identificationLocked(ctx context.Context) (Identification, error) {
+	if err := sendRequest(ctx, s, 0x37, []byte{}); err != nil {
 		return Identification{}, err
 	}
 
-	response, err := readResponse(s)
+	response, err := readResponse(ctx, s)
 
 	if err != nil {
 		return Identification{}, err
@@ -246,14 +493,28 @@ func (s *CCValidator) Identification() (Identification, error) {
 	}, nil
 }
 
-func (s *CCValidator) GetBillTable() ([]Bill, error) {
-	err := sendRequest(s, 0x41, []byte{})
+func (s *CCValidator) Identification() (Identification, error) {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.IdentificationContext(ctx)
+}
+
+func (s *CCValidator) GetBillTableContext(ctx context.Context) ([]Bill, error) {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	return s.getBillTableLocked(ctx)
+}
+
+// getBillTableLocked is GetBillTableContext's body, called directly by
+// recover (see resetLocked) so it doesn't re-lock s.dispatch.
+func (s *CCValidator) getBillTableLocked(ctx context.Context) ([]Bill, error) {
+	if err := sendRequest(ctx, s, 0x41, []byte{}); err != nil {
 		return nil, err
 	}
 
-	response, err := readResponse(s)
+	response, err := readResponse(ctx, s)
 
 	if err != nil {
 		return nil, err
@@ -280,7 +541,23 @@ func (s *CCValidator) GetBillTable() ([]Bill, error) {
 	return bills, nil
 }
 
-func (s *CCValidator) EnableBillTypes(enabled []uint, escrow []uint) error {
+func (s *CCValidator) GetBillTable() ([]Bill, error) {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
+
+	return s.GetBillTableContext(ctx)
+}
+
+func (s *CCValidator) EnableBillTypesContext(ctx context.Context, enabled []uint, escrow []uint) error {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	return s.enableBillTypesLocked(ctx, enabled, escrow)
+}
+
+// enableBillTypesLocked is EnableBillTypesContext's body, called directly by
+// recover (see resetLocked) so it doesn't re-lock s.dispatch.
+func (s *CCValidator) enableBillTypesLocked(ctx context.Context, enabled []uint, escrow []uint) error {
 	enabledBytes := []byte{0, 0, 0}
 	escrowBytes := []byte{0, 0, 0}
 
@@ -294,181 +571,394 @@ func (s *CCValidator) EnableBillTypes(enabled []uint, escrow []uint) error {
 		escrowBytes[pos/8] |= 1 << (7 - pos + pos/8*8)
 	}
 
-	err := sendRequest(s, 0x34, append(enabledBytes, escrowBytes...))
+	if err := sendRequest(ctx, s, 0x34, append(enabledBytes, escrowBytes...)); err != nil {
+		return err
+	}
 
-	if err != nil {
+	if _, err := readResponse(ctx, s); err != nil {
 		return err
 	}
 
-	_, err = readResponse(s)
-	return err
+	if s.cfg != nil {
+		s.cfg.setEnabled(enabled, escrow)
+	}
+	return nil
 }
 
-func (s *CCValidator) Stack() error {
-	err := sendRequest(s, 0x35, []byte{})
+func (s *CCValidator) EnableBillTypes(enabled []uint, escrow []uint) error {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.EnableBillTypesContext(ctx, enabled, escrow)
+}
+
+func (s *CCValidator) StackContext(ctx context.Context) error {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	if err := sendRequest(ctx, s, 0x35, []byte{}); err != nil {
 		return err
 	}
 
-	_, err = readResponse(s)
+	_, err := readResponse(ctx, s)
 	return err
 }
 
-func (s *CCValidator) Return() error {
-	err := sendRequest(s, 0x36, []byte{})
+func (s *CCValidator) Stack() error {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.StackContext(ctx)
+}
+
+func (s *CCValidator) ReturnContext(ctx context.Context) error {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	if err := sendRequest(ctx, s, 0x36, []byte{}); err != nil {
 		return err
 	}
 
-	_, err = readResponse(s)
+	_, err := readResponse(ctx, s)
 	return err
 }
 
-func (s *CCValidator) Hold() error {
-	err := sendRequest(s, 0x38, []byte{})
+func (s *CCValidator) Return() error {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.ReturnContext(ctx)
+}
+
+func (s *CCValidator) HoldContext(ctx context.Context) error {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	if err := sendRequest(ctx, s, 0x38, []byte{}); err != nil {
 		return err
 	}
 
-	_, err = readResponse(s)
+	_, err := readResponse(ctx, s)
 	return err
 }
 
-func (s *CCValidator) GetCRC32() ([]byte, error) {
-	err := sendRequest(s, 0x51, []byte{})
+func (s *CCValidator) Hold() error {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.HoldContext(ctx)
+}
+
+func (s *CCValidator) GetCRC32Context(ctx context.Context) ([]byte, error) {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	if err := sendRequest(ctx, s, 0x51, []byte{}); err != nil {
 		return nil, err
 	}
 
-	return readResponse(s)
+	return readResponse(ctx, s)
 }
 
-func (s *CCValidator) SetBarcodeParameters(format byte, numberOfCharacters byte) error {
-	err := sendRequest(s, 0x3A, []byte{format, numberOfCharacters})
+func (s *CCValidator) GetCRC32() ([]byte, error) {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.GetCRC32Context(ctx)
+}
+
+func (s *CCValidator) SetBarcodeParametersContext(ctx context.Context, format byte, numberOfCharacters byte) error {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	if err := sendRequest(ctx, s, 0x3A, []byte{format, numberOfCharacters}); err != nil {
 		return err
 	}
 
-	_, err = readResponse(s)
+	_, err := readResponse(ctx, s)
 	return err
 }
 
-func (s *CCValidator) ExtractBarcodeData() ([]byte, error) {
-	err := sendRequest(s, 0x3A, []byte{})
+func (s *CCValidator) SetBarcodeParameters(format byte, numberOfCharacters byte) error {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.SetBarcodeParametersContext(ctx, format, numberOfCharacters)
+}
+
+func (s *CCValidator) ExtractBarcodeDataContext(ctx context.Context) ([]byte, error) {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	if err := sendRequest(ctx, s, 0x3A, []byte{}); err != nil {
 		return nil, err
 	}
 
-	return readResponse(s)
+	return readResponse(ctx, s)
 }
 
-func (s *CCValidator) Ack() error {
-	err := sendRequest(s, 0x00, []byte{})
+func (s *CCValidator) ExtractBarcodeData() ([]byte, error) {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
 
-	if err != nil {
+	return s.ExtractBarcodeDataContext(ctx)
+}
+
+func (s *CCValidator) AckContext(ctx context.Context) error {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
+
+	if err := sendRequest(ctx, s, 0x00, []byte{}); err != nil {
 		return err
 	}
 
-	_, err = readResponse(s)
+	_, err := readResponse(ctx, s)
 	return err
 }
 
+func (s *CCValidator) Ack() error {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
+
+	return s.AckContext(ctx)
+}
+
+// AckContext sends a fire-and-forget ACK for the response just read by
+// readResponse. It does not wait for a reply, matching how the device treats
+// ACK/NAK as transport-level acknowledgements rather than commands.
+func AckContext(ctx context.Context, s *CCValidator) {
+	_ = sendRequest(ctx, s, 0x00, []byte{})
+}
+
 func Ack(s *CCValidator) {
-	_ = sendRequest(s, 0x00, []byte{})
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
+
+	AckContext(ctx, s)
 }
 
-func (s *CCValidator) Nack() error {
-	err := sendRequest(s, 0xFF, []byte{})
+func (s *CCValidator) NackContext(ctx context.Context) error {
+	s.dispatch.Lock()
+	defer s.dispatch.Unlock()
 
-	if err != nil {
+	if err := sendRequest(ctx, s, 0xFF, []byte{}); err != nil {
 		return err
 	}
 
-	_, err = readResponse(s)
+	_, err := readResponse(ctx, s)
 	return err
 }
 
-func readResponse(v *CCValidator) ([]byte, error) {
-	var buf []byte
-	innerBuf := make([]byte, 256)
+func (s *CCValidator) Nack() error {
+	ctx, cancel := s.backgroundContext()
+	defer cancel()
+
+	return s.NackContext(ctx)
+}
+
+// readFrame reads exactly one CCNet frame from v.port: SYNC/ADR/LNG via
+// io.ReadFull, then the remaining LNG-3 bytes (data plus the 2-byte CRC) in a
+// single further io.ReadFull, so a short read never gets misread as a
+// complete frame. It returns the frame with the header intact but the CRC
+// stripped, once the CRC has been verified.
+func readFrame(ctx context.Context, v *CCValidator) ([]byte, error) {
+	header := make([]byte, 3)
 
-	totalRead := 0
-	readTriesCount := 0
-	maxReadCount := 1050
+	if err := v.reads.readFull(ctx, v.port, header); err != nil {
+		return nil, err
+	}
 
-	for ; ; {
-		readTriesCount += 1
+	if header[0] != StartCode || header[1] != PeripheralAddress {
+		return nil, fmt.Errorf("response format invalid")
+	}
 
-		if readTriesCount >= maxReadCount {
-			return nil, fmt.Errorf("Reads tries exceeded")
-		}
+	length := int(header[2])
+
+	if length == 0 {
+		// LNG==0 signals a 4-byte big-length extension field for frames over
+		// 255 bytes; none of the commands this package issues produce one.
+		return nil, fmt.Errorf("extended-length frames are not supported")
+	}
+
+	if length < 6 {
+		return nil, fmt.Errorf("response format invalid")
+	}
 
-		n, err := v.port.Read(innerBuf)
+	rest := make([]byte, length-3)
 
-		if err != nil {
-			return nil, err
+	if err := v.reads.readFull(ctx, v.port, rest); err != nil {
+		return nil, err
+	}
+
+	frame := append(header, rest...)
+
+	crc := binary.LittleEndian.Uint16(frame[len(frame)-2:])
+	frame = frame[:len(frame)-2]
+
+	if crc != GetCRC16(frame) {
+		return nil, fmt.Errorf("response verification failed")
+	}
+
+	return frame, nil
+}
+
+// pendingRead is the outcome of a readFull goroutine, delivered once
+// io.ReadFull returns.
+type pendingRead struct {
+	err error
+}
+
+// serialReadQueue runs each readFull on its own goroutine, exactly as before,
+// but never lets more than one such goroutine read from the connection at a
+// time. If ctx expires before a read completes, the goroutine is left
+// running and recorded as pending; the next call waits for it to finish (or
+// for its own ctx to expire) before issuing a read of its own. Without this,
+// a second goroutine reading concurrently with an abandoned first one would
+// race it for bytes on the wire — and since CCNet frames carry no request
+// ID, there would be no way to tell which goroutine got which bytes, so the
+// frame boundary the next caller expects would desync for good.
+type serialReadQueue struct {
+	mu      sync.Mutex
+	pending chan pendingRead
+}
+
+// maxIdleReads bounds how many consecutive zero-byte reads readFullBounded
+// tolerates before giving up, mirroring the old readResponse's
+// maxReadCount=1050: tarm/serial.Port.Read reports an ordinary read timeout
+// as (0, nil), not an error, and io.ReadFull treats that as "keep trying"
+// forever, so without this bound a validator that never answers hangs (and,
+// against a reader that doesn't pace itself on real hardware timing, pegs a
+// core) instead of eventually surfacing an error.
+const maxIdleReads = 1050
+
+func (q *serialReadQueue) readFull(ctx context.Context, r io.Reader, buf []byte) error {
+	if err := q.awaitPending(ctx); err != nil {
+		return err
+	}
+
+	result := make(chan pendingRead, 1)
+
+	go func() {
+		result <- pendingRead{err: readFullBounded(r, buf)}
+	}()
+
+	q.mu.Lock()
+	q.pending = result
+	q.mu.Unlock()
+
+	select {
+	case res := <-result:
+		q.clearPending(result)
+		return res.err
+	case <-ctx.Done():
+		// The read above is still running and has no way to be cancelled
+		// mid-call; if r is a supervisedPort, force its connection closed so
+		// the stuck Read actually returns (and the supervisor reconnects)
+		// instead of leaving the goroutine — and the stalled connection —
+		// running until maxIdleReads elapses on its own.
+		if sp, ok := r.(*supervisedPort); ok {
+			sp.triggerReconnect()
 		}
+		return ctx.Err()
+	}
+}
 
-		totalRead += n
-		buf = append(buf, innerBuf[:n]...)
+// readFullBounded is io.ReadFull with one change: a Read that returns
+// (0, nil) — the timeout tarm/serial.Port.Read reports instead of an error —
+// counts against maxIdleReads rather than being retried forever.
+func readFullBounded(r io.Reader, buf []byte) error {
+	n := 0
+	idle := 0
 
-		if totalRead < 6 {
-			continue
+	for n < len(buf) {
+		nn, err := r.Read(buf[n:])
+		n += nn
+
+		if err != nil {
+			if n > 0 && errors.Is(err, io.EOF) {
+				return io.ErrUnexpectedEOF
+			}
+			return err
 		}
-		if buf[2] != 0x0 && int(buf[2]) != len(buf) {
+
+		if nn == 0 {
+			idle++
+			if idle >= maxIdleReads {
+				return fmt.Errorf("read tries exceeded")
+			}
 			continue
 		}
 
-		break
+		idle = 0
 	}
 
-	if buf[0] != StartCode || buf[1] != PeripheralAddress {
-		return nil, fmt.Errorf("Response format invalid")
-	}
+	return nil
+}
+
+// awaitPending drains a goroutine left running by a previous, abandoned
+// readFull call, so this call never starts reading while that one is still
+// in flight.
+func (q *serialReadQueue) awaitPending(ctx context.Context) error {
+	for {
+		q.mu.Lock()
+		pending := q.pending
+		q.mu.Unlock()
+
+		if pending == nil {
+			return nil
+		}
 
-	crc := binary.LittleEndian.Uint16(buf[len(buf)-2:])
+		select {
+		case <-pending:
+			q.clearPending(pending)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
 
-	buf = buf[:len(buf)-2]
+func (q *serialReadQueue) clearPending(done chan pendingRead) {
+	q.mu.Lock()
+	if q.pending == done {
+		q.pending = nil
+	}
+	q.mu.Unlock()
+}
 
-	crc2 := GetCRC16(buf)
+func readResponse(ctx context.Context, v *CCValidator) ([]byte, error) {
+	buf, err := readFrame(ctx, v)
 
-	if crc != crc2 {
-		return nil, fmt.Errorf("Response verification failed")
+	if err != nil {
+		return nil, err
 	}
 
 	if len(buf) == 4 && buf[3] == 0x00 {
-		if v.logging {
-			fmt.Printf("validator[%v]: <- %X\n", v.config.Name, buf)
-		}
+		v.logger.Debugf("validator[%v]: <- %X", v.name, buf)
 		return nil, nil // TODO Ack
 	}
 
 	if len(buf) == 4 && buf[3] == 0xFF {
-		return nil, fmt.Errorf("Nack")
+		return nil, fmt.Errorf("nack")
 	}
 
 	if len(buf) == 4 && buf[3] == 0x30 {
-		return nil, fmt.Errorf("Illegal command")
+		return nil, fmt.Errorf("illegal command")
 	}
 
 	buf = buf[3:]
 
-	if v.logging {
-		fmt.Printf("validator[%v]: <- %X\n", v.config.Name, buf)
-	}
+	v.logger.Debugf("validator[%v]: <- %X", v.name, buf)
 
-	Ack(v)
+	AckContext(ctx, v)
 
 	return buf, nil
 }
 
-func sendRequest(v *CCValidator, commandCode byte, bytesData ...[]byte) error {
+func sendRequest(ctx context.Context, v *CCValidator, commandCode byte, bytesData ...[]byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if !v.open {
 		return errors.New("serial port is closed")
 	}
@@ -494,9 +984,7 @@ func sendRequest(v *CCValidator, commandCode byte, bytesData ...[]byte) error {
 
 	_ = binary.Write(buf, binary.LittleEndian, crc)
 
-	if v.logging {
-		fmt.Printf("validator[%v]:-> %X\n", v.config.Name, buf.Bytes())
-	}
+	v.logger.Debugf("validator[%v]: -> %X", v.name, buf.Bytes())
 
 	_, err := v.port.Write(buf.Bytes())
 	return err
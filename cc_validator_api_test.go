@@ -9,7 +9,7 @@ import (
 )
 
 func TestCanReadCard(t *testing.T) {
-	c, er := api.NewConnection("COM4", api.Baud9600)
+	c, er := api.NewConnection("COM4", api.WithBaud(api.Baud9600))
 
 	//fmt.Println(r)
 	if er != nil {
@@ -45,7 +45,7 @@ func TestCanReadCard(t *testing.T) {
 			break
 		}
 
-		fmt.Sprintf("%X %X", status, param)
+		fmt.Printf("%X %X\n", status, param)
 	}
 
 }
@@ -0,0 +1,515 @@
+// Command ccvalidator talks to a CCValidator over a configurable serial
+// path, for interactive hardware debugging and scripted operation. It
+// doubles as a reference for wiring up the cc_validator_api package: see
+// connect below for how the functional options line up with a --config
+// file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	api "cc_validator_api"
+
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmdName, args := os.Args[1], os.Args[2:]
+
+	var run func([]string) error
+
+	switch cmdName {
+	case "identify":
+		run = cmdIdentify
+	case "bills":
+		run = cmdBills
+	case "status":
+		run = cmdStatus
+	case "enable":
+		run = cmdEnable
+	case "accept":
+		run = cmdAccept
+	case "monitor":
+		run = cmdMonitor
+	case "reset":
+		run = cmdReset
+	case "help", "-h", "--help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "ccvalidator: unknown command %q\n\n", cmdName)
+		usage()
+		os.Exit(2)
+	}
+
+	if err := run(args); err != nil {
+		fmt.Fprintf(os.Stderr, "ccvalidator %s: %v\n", cmdName, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `ccvalidator is a CLI for exercising a cc_validator_api.CCValidator.
+
+Usage:
+
+	ccvalidator <command> [flags]
+
+Commands:
+
+	identify   print Identification as JSON
+	bills      print the bill table
+	status     print the enabled/security bitmaps
+	enable     enable bill types for acceptance, optionally into escrow
+	accept     wait for a bill, then stack or return it
+	monitor    stream Poll results as newline-delimited JSON
+	reset      reset the validator
+
+Every command accepts:
+
+	-config string   YAML file mirroring NewConnection's options
+	-path string     serial device path (overrides --config)
+	-v               log protocol traces to stderr
+
+Run "ccvalidator <command> -h" for a command's own flags.
+`)
+}
+
+// config mirrors the functional options NewConnection accepts, so a
+// --config file can set everything a flag can.
+type config struct {
+	Path         string
+	Baud         int
+	DataBits     int
+	ReadTimeout  time.Duration
+	PollInterval time.Duration
+	Verbose      bool
+}
+
+// rawConfig is a --config file's on-disk shape: the same fields as config,
+// but ReadTimeout/PollInterval as duration strings (e.g. "30s"), since
+// gopkg.in/yaml.v3 has no native time.Duration support and would otherwise
+// only accept raw nanosecond integers.
+type rawConfig struct {
+	Path         string `yaml:"path"`
+	Baud         int    `yaml:"baud"`
+	DataBits     int    `yaml:"data_bits"`
+	ReadTimeout  string `yaml:"read_timeout"`
+	PollInterval string `yaml:"poll_interval"`
+	Verbose      bool   `yaml:"verbose"`
+}
+
+func defaultConfig() config {
+	return config{
+		Path:         "/dev/ttyUSB0",
+		Baud:         int(api.Baud9600),
+		DataBits:     8,
+		ReadTimeout:  5 * time.Second,
+		PollInterval: 150 * time.Millisecond,
+	}
+}
+
+func (c config) toRaw() rawConfig {
+	return rawConfig{
+		Path:         c.Path,
+		Baud:         c.Baud,
+		DataBits:     c.DataBits,
+		ReadTimeout:  c.ReadTimeout.String(),
+		PollInterval: c.PollInterval.String(),
+		Verbose:      c.Verbose,
+	}
+}
+
+func (r rawConfig) parse() (config, error) {
+	readTimeout, err := time.ParseDuration(r.ReadTimeout)
+	if err != nil {
+		return config{}, fmt.Errorf("read_timeout: %w", err)
+	}
+
+	pollInterval, err := time.ParseDuration(r.PollInterval)
+	if err != nil {
+		return config{}, fmt.Errorf("poll_interval: %w", err)
+	}
+
+	return config{
+		Path:         r.Path,
+		Baud:         r.Baud,
+		DataBits:     r.DataBits,
+		ReadTimeout:  readTimeout,
+		PollInterval: pollInterval,
+		Verbose:      r.Verbose,
+	}, nil
+}
+
+func readConfig(path string) (config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return config{}, err
+	}
+	defer f.Close()
+
+	raw := defaultConfig().toRaw()
+
+	if err := yaml.NewDecoder(f).Decode(&raw); err != nil && err != io.EOF {
+		return config{}, err
+	}
+
+	return raw.parse()
+}
+
+// commonFlags registers the flags every subcommand accepts and returns a
+// func that resolves them into a config once fs has been parsed.
+func commonFlags(fs *flag.FlagSet) func() (config, error) {
+	cfgPath := fs.String("config", "", "YAML file mirroring NewConnection's options")
+	path := fs.String("path", "", "serial device path (overrides --config)")
+	verbose := fs.Bool("v", false, "log protocol traces to stderr")
+
+	return func() (config, error) {
+		cfg := defaultConfig()
+
+		if *cfgPath != "" {
+			loaded, err := readConfig(*cfgPath)
+			if err != nil {
+				return config{}, fmt.Errorf("reading %s: %w", *cfgPath, err)
+			}
+			cfg = loaded
+		}
+
+		if *path != "" {
+			cfg.Path = *path
+		}
+		if *verbose {
+			cfg.Verbose = true
+		}
+
+		return cfg, nil
+	}
+}
+
+func connect(cfg config) (api.CCValidator, error) {
+	opts := []api.Option{
+		api.WithBaud(api.Baud(cfg.Baud)),
+		api.WithDataBits(cfg.DataBits),
+		api.WithReadTimeout(cfg.ReadTimeout),
+		api.WithPollInterval(cfg.PollInterval),
+	}
+
+	if cfg.Verbose {
+		opts = append(opts, api.WithLogger(stderrLogger{}))
+	}
+
+	return api.NewConnection(cfg.Path, opts...)
+}
+
+// stderrLogger adapts api.Logger to plain stderr lines, so -v has somewhere
+// to go without pulling in a logging library just for a CLI flag.
+type stderrLogger struct{}
+
+func (stderrLogger) Debugf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "DEBUG "+format+"\n", args...)
+}
+
+func (stderrLogger) Infof(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "INFO  "+format+"\n", args...)
+}
+
+func (stderrLogger) Warnf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "WARN  "+format+"\n", args...)
+}
+
+func (stderrLogger) Errorf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "ERROR "+format+"\n", args...)
+}
+
+func cmdIdentify(args []string) error {
+	fs := flag.NewFlagSet("identify", flag.ExitOnError)
+	resolve := commonFlags(fs)
+	fs.Parse(args)
+
+	cfg, err := resolve()
+	if err != nil {
+		return err
+	}
+
+	v, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+
+	ident, err := v.Identification()
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(ident)
+}
+
+func cmdBills(args []string) error {
+	fs := flag.NewFlagSet("bills", flag.ExitOnError)
+	resolve := commonFlags(fs)
+	fs.Parse(args)
+
+	cfg, err := resolve()
+	if err != nil {
+		return err
+	}
+
+	v, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+
+	bills, err := v.GetBillTable()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tCOUNTRY\tDENOMINATION")
+
+	for i, b := range bills {
+		if b.Denomination == 0 && b.CountryCode == "" {
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%g\n", i, b.CountryCode, b.Denomination)
+	}
+
+	return w.Flush()
+}
+
+func cmdStatus(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	resolve := commonFlags(fs)
+	fs.Parse(args)
+
+	cfg, err := resolve()
+	if err != nil {
+		return err
+	}
+
+	v, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+
+	enabled, security, err := v.GetStatus()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("enabled:  %v\n", enabled)
+	fmt.Printf("security: %v\n", security)
+
+	return nil
+}
+
+func cmdEnable(args []string) error {
+	fs := flag.NewFlagSet("enable", flag.ExitOnError)
+	resolve := commonFlags(fs)
+	types := fs.String("types", "", "comma-separated bill types to enable, e.g. 0,1,2,3")
+	escrow := fs.String("escrow", "", "comma-separated bill types to hold in escrow for manual accept/return")
+	fs.Parse(args)
+
+	cfg, err := resolve()
+	if err != nil {
+		return err
+	}
+
+	enabled, err := parseUints(*types)
+	if err != nil {
+		return fmt.Errorf("--types: %w", err)
+	}
+
+	escrowTypes, err := parseUints(*escrow)
+	if err != nil {
+		return fmt.Errorf("--escrow: %w", err)
+	}
+
+	v, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+
+	return v.EnableBillTypes(enabled, escrowTypes)
+}
+
+func parseUints(s string) ([]uint, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var out []uint
+
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.ParseUint(strings.TrimSpace(part), 10, 8)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, uint(n))
+	}
+
+	return out, nil
+}
+
+func cmdAccept(args []string) error {
+	fs := flag.NewFlagSet("accept", flag.ExitOnError)
+	resolve := commonFlags(fs)
+	timeout := fs.Duration("timeout", 30*time.Second, "how long to wait for a bill to reach escrow")
+	action := fs.String("action", "stack", `what to do with an escrowed bill: "stack" or "return"`)
+	fs.Parse(args)
+
+	if *action != "stack" && *action != "return" {
+		return fmt.Errorf(`--action must be "stack" or "return", got %q`, *action)
+	}
+
+	cfg, err := resolve()
+	if err != nil {
+		return err
+	}
+
+	v, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+
+	if err := v.Reset(); err != nil {
+		return err
+	}
+
+	billTable, err := v.GetBillTable()
+	if err != nil {
+		return err
+	}
+
+	types := allTypes(billTable)
+	if err := v.EnableBillTypes(types, types); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	session := api.NewSession(&v, cfg.PollInterval)
+	defer session.Close()
+
+	for {
+		select {
+		case ev, ok := <-session.Events():
+			if !ok {
+				return errors.New("session closed before a bill reached escrow")
+			}
+
+			escrowed, ok := ev.(api.BillEscrowed)
+			if !ok {
+				continue
+			}
+
+			fmt.Fprintf(os.Stderr, "escrowed: type %d, %s %g\n", escrowed.Type, escrowed.Bill.CountryCode, escrowed.Bill.Denomination)
+
+			if *action == "return" {
+				return session.ReturnBill(ctx)
+			}
+
+			bill, err := session.Accept(ctx)
+			if err != nil {
+				return err
+			}
+
+			return json.NewEncoder(os.Stdout).Encode(bill)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func allTypes(bills []api.Bill) []uint {
+	var types []uint
+
+	for i, b := range bills {
+		if b.Denomination == 0 && b.CountryCode == "" {
+			continue
+		}
+		types = append(types, uint(i))
+	}
+
+	return types
+}
+
+func cmdMonitor(args []string) error {
+	fs := flag.NewFlagSet("monitor", flag.ExitOnError)
+	resolve := commonFlags(fs)
+	fs.Parse(args)
+
+	cfg, err := resolve()
+	if err != nil {
+		return err
+	}
+
+	v, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+
+	enc := json.NewEncoder(os.Stdout)
+
+	ticker := time.NewTicker(cfg.PollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		status, param, err := v.Poll()
+		if err != nil {
+			return err
+		}
+
+		if err := enc.Encode(pollResult{Status: status, Param: param}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type pollResult struct {
+	Status api.Status `json:"status"`
+	Param  byte       `json:"param"`
+}
+
+func cmdReset(args []string) error {
+	fs := flag.NewFlagSet("reset", flag.ExitOnError)
+	resolve := commonFlags(fs)
+	fs.Parse(args)
+
+	cfg, err := resolve()
+	if err != nil {
+		return err
+	}
+
+	v, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+
+	return v.Reset()
+}
@@ -0,0 +1,43 @@
+package cc_validator_api
+
+import "fmt"
+
+// Logger is the subset of a structured logger CCValidator needs. It is
+// satisfied by a thin adapter over log/slog, logrus, or zap's
+// SugaredLogger, so callers can plug in whatever they already use instead
+// of the package writing straight to stdout.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// noopLogger is the default Logger: it discards everything, so a
+// CCValidator constructed without WithLogger (or the legacy logging=false)
+// stays silent, as it always has.
+type noopLogger struct{}
+
+func (noopLogger) Debugf(string, ...interface{}) {}
+func (noopLogger) Infof(string, ...interface{})  {}
+func (noopLogger) Warnf(string, ...interface{})  {}
+func (noopLogger) Errorf(string, ...interface{}) {}
+
+// printfLogger adapts the legacy logging=true behavior (trace lines written
+// to stdout) to Logger, so NewConnectionClassic keeps producing exactly the
+// output it always has.
+type printfLogger struct{}
+
+func (printfLogger) Debugf(format string, args ...interface{}) { fmt.Printf(format+"\n", args...) }
+func (printfLogger) Infof(format string, args ...interface{})  { fmt.Printf(format+"\n", args...) }
+func (printfLogger) Warnf(format string, args ...interface{})  { fmt.Printf(format+"\n", args...) }
+func (printfLogger) Errorf(format string, args ...interface{}) { fmt.Printf(format+"\n", args...) }
+
+// loggerFor maps the legacy bool flag onto a Logger, for the handful of
+// constructors that still take one.
+func loggerFor(logging bool) Logger {
+	if logging {
+		return printfLogger{}
+	}
+	return noopLogger{}
+}
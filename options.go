@@ -0,0 +1,80 @@
+package cc_validator_api
+
+import (
+	"time"
+
+	"github.com/tarm/serial"
+)
+
+// defaultPollInterval is the poll cadence CCValidator reports via
+// PollInterval when the caller didn't set one with WithPollInterval; it
+// matches the interval CCNet hosts are expected to poll at.
+const defaultPollInterval = 150 * time.Millisecond
+
+// defaultReadTimeout is the read timeout CCValidator reports via
+// ReadTimeout when the caller didn't set one with WithReadTimeout.
+const defaultReadTimeout = 5 * time.Second
+
+type options struct {
+	baud         Baud
+	parity       serial.Parity
+	stopBits     serial.StopBits
+	dataBits     int
+	readTimeout  time.Duration
+	pollInterval time.Duration
+	logger       Logger
+}
+
+func defaultOptions() options {
+	return options{
+		baud:         Baud9600,
+		parity:       serial.ParityNone,
+		stopBits:     serial.Stop1,
+		dataBits:     8,
+		readTimeout:  defaultReadTimeout,
+		pollInterval: defaultPollInterval,
+		logger:       noopLogger{},
+	}
+}
+
+// Option configures a CCValidator constructed with NewConnection.
+type Option func(*options)
+
+// WithBaud sets the serial baud rate. Defaults to Baud9600.
+func WithBaud(baud Baud) Option {
+	return func(o *options) { o.baud = baud }
+}
+
+// WithParity sets the serial parity. Defaults to serial.ParityNone.
+func WithParity(parity serial.Parity) Option {
+	return func(o *options) { o.parity = parity }
+}
+
+// WithStopBits sets the number of serial stop bits. Defaults to
+// serial.Stop1.
+func WithStopBits(stopBits serial.StopBits) Option {
+	return func(o *options) { o.stopBits = stopBits }
+}
+
+// WithDataBits sets the number of serial data bits. Defaults to 8.
+func WithDataBits(dataBits int) Option {
+	return func(o *options) { o.dataBits = dataBits }
+}
+
+// WithReadTimeout sets how long a read on the serial port blocks before
+// returning with no data. Defaults to 5s.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.readTimeout = timeout }
+}
+
+// WithPollInterval sets the interval CCValidator.PollInterval reports back
+// to callers such as NewSession. Defaults to 150ms.
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *options) { o.pollInterval = interval }
+}
+
+// WithLogger routes the validator's trace and state-transition logging
+// through logger instead of discarding it.
+func WithLogger(logger Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
@@ -0,0 +1,75 @@
+package cc_validator_api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+// TestSerialReadQueueDoesNotRaceAbandonedRead guards against the goroutine
+// backing a timed-out readFull racing a later call for bytes on the same
+// connection; see the chunk0-2/chunk0-4 review fix.
+func TestSerialReadQueueDoesNotRaceAbandonedRead(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	q := &serialReadQueue{}
+
+	short, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	first := make([]byte, 3)
+	if err := q.readFull(short, pr, first); err != context.DeadlineExceeded {
+		t.Fatalf("first readFull err = %v, want context.DeadlineExceeded", err)
+	}
+
+	go func() {
+		_, _ = pw.Write([]byte{0xAA, 0xAA, 0xAA}) // belongs to the abandoned read above
+		_, _ = pw.Write([]byte{0xBB, 0xBB, 0xBB}) // belongs to the call below
+	}()
+
+	long, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+
+	second := make([]byte, 3)
+	if err := q.readFull(long, pr, second); err != nil {
+		t.Fatalf("second readFull: %v", err)
+	}
+
+	if want := []byte{0xBB, 0xBB, 0xBB}; !bytes.Equal(second, want) {
+		t.Fatalf("second readFull got %X, want %X (it raced the abandoned first read instead of waiting for it)", second, want)
+	}
+}
+
+// idleReader always reports a read timeout the way tarm/serial.Port.Read
+// does: (0, nil), never an error. io.ReadFull retries that forever; see the
+// chunk0-2 review fix.
+type idleReader struct{}
+
+func (idleReader) Read([]byte) (int, error) {
+	return 0, nil
+}
+
+// TestSerialReadQueueBoundsIdleReads guards against readFull hanging
+// forever against a reader that never returns an error, only (0, nil):
+// without maxIdleReads, an unbounded context (or one with a long deadline)
+// would never return, see the chunk0-2 review fix.
+func TestSerialReadQueueBoundsIdleReads(t *testing.T) {
+	q := &serialReadQueue{}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.readFull(context.Background(), idleReader{}, make([]byte, 3))
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("readFull against an always-idle reader returned nil, want an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("readFull against an always-idle reader never returned")
+	}
+}
@@ -0,0 +1,347 @@
+package cc_validator_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event is published on a Session's event channel whenever a poll observes a
+// status change. The concrete type identifies what happened.
+type Event interface {
+	isEvent()
+}
+
+// BillEscrowed fires when the validator reaches EscrowPosition: a bill has
+// been accepted and is being held pending Session.Accept or
+// Session.ReturnBill.
+type BillEscrowed struct {
+	Type uint
+	Bill Bill
+}
+
+// BillStackedEvent fires once an escrowed bill has been stacked.
+type BillStackedEvent struct {
+	Bill Bill
+}
+
+// BillReturnedEvent fires once an escrowed bill has been returned to the
+// customer.
+type BillReturnedEvent struct{}
+
+// RejectedEvent fires when the validator rejects an inserted bill; Code is
+// one of the DueTo* constants.
+type RejectedEvent struct {
+	Code byte
+}
+
+// FailureEvent fires on a hardware failure status; Code is one of the
+// *Failure constants where the validator supplied one, or the raw Status
+// otherwise.
+type FailureEvent struct {
+	Code byte
+}
+
+// CassetteRemoved fires when the drop cassette is removed or out of
+// position, reported by the validator as DropCassetteOutOfPosition.
+type CassetteRemoved struct{}
+
+// CassetteFull fires when the drop cassette has reached capacity.
+type CassetteFull struct{}
+
+// Reconnected fires when the underlying transport has been lost and
+// transparently reopened by the reconnect supervisor (see
+// NewConnectionWithTransport), after its configuration has been restored.
+type Reconnected struct{}
+
+func (BillEscrowed) isEvent()      {}
+func (BillStackedEvent) isEvent()  {}
+func (BillReturnedEvent) isEvent() {}
+func (RejectedEvent) isEvent()     {}
+func (FailureEvent) isEvent()      {}
+func (CassetteRemoved) isEvent()   {}
+func (CassetteFull) isEvent()      {}
+func (Reconnected) isEvent()       {}
+
+// Session wraps a CCValidator with a background poll loop that diffs
+// consecutive statuses and publishes typed Events, so callers don't have to
+// hand-roll a `for { time.Sleep; c.Poll() }` loop and correlate Status with
+// param themselves.
+type Session struct {
+	validator *CCValidator
+	billTable []Bill
+
+	// ioMu serializes every request/response cycle the Session issues
+	// against validator. CCValidator itself isn't safe for concurrent use,
+	// and the poll loop below runs concurrently with Accept/ReturnBill.
+	ioMu sync.Mutex
+
+	events   chan Event
+	terminal chan Event
+
+	// terminalPending reports whether an Accept/ReturnBill is actually
+	// waiting on terminal right now, so run only delivers a terminal event
+	// to it when one is: without this, a terminal event produced with
+	// nothing pending (a device-initiated reject/return, or one left behind
+	// by a call that gave up via ctx before claiming it) sits in the size-1
+	// buffer and is handed to the next Accept/ReturnBill as if it were that
+	// call's own outcome.
+	terminalPending atomic.Bool
+
+	// closeMu guards closed and events' lifetime against publishReconnected,
+	// which runs on whatever goroutine the supervisor's reconnect completes
+	// on and so can fire concurrently with, or after, Close. Close sets
+	// closed and closes events in the same critical section that
+	// publishReconnected checks closed and sends in, so a reconnect that
+	// lands after Close can never send on the now-closed channel.
+	closeMu sync.Mutex
+	closed  bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSession starts polling validator in the background every pollInterval
+// (100-200ms, per CCNet's polling requirement) and returns a Session whose
+// Events channel carries the resulting state transitions. If pollInterval is
+// zero, validator.PollInterval() is used instead. The bill table is fetched
+// once up front, best-effort, so BillEscrowed and BillStackedEvent can carry
+// the matching Bill.
+func NewSession(validator *CCValidator, pollInterval time.Duration) *Session {
+	if pollInterval <= 0 {
+		pollInterval = validator.PollInterval()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	billTable, _ := validator.GetBillTableContext(ctx)
+
+	s := &Session{
+		validator: validator,
+		billTable: billTable,
+		events:    make(chan Event, 16),
+		terminal:  make(chan Event, 1),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	validator.OnReconnect(s.publishReconnected)
+
+	go s.run(ctx, pollInterval)
+
+	return s
+}
+
+// publishReconnected is registered with validator.OnReconnect and makes a
+// best-effort, non-blocking attempt to surface the reconnect on Events; it
+// must not block, since it runs synchronously inside the supervisor's
+// reconnect path. It must also not fire once Close has closed events: see
+// closeMu.
+func (s *Session) publishReconnected() {
+	s.closeMu.Lock()
+	defer s.closeMu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.events <- Reconnected{}:
+	default:
+	}
+}
+
+// Events returns the channel Events are published on. It is closed once the
+// poll loop has stopped, i.e. after Close has been called.
+func (s *Session) Events() <-chan Event {
+	return s.events
+}
+
+// Accept issues Stack in response to the bill currently in escrow and blocks
+// until the terminal event for it arrives, returning the stacked Bill.
+func (s *Session) Accept(ctx context.Context) (Bill, error) {
+	s.startAwaitingTerminal()
+
+	s.ioMu.Lock()
+	err := s.validator.StackContext(ctx)
+	s.ioMu.Unlock()
+
+	if err != nil {
+		s.terminalPending.Store(false)
+		return Bill{}, err
+	}
+
+	return s.awaitTerminal(ctx)
+}
+
+// ReturnBill issues Return in response to the bill currently in escrow and
+// blocks until the terminal event for it arrives.
+func (s *Session) ReturnBill(ctx context.Context) error {
+	s.startAwaitingTerminal()
+
+	s.ioMu.Lock()
+	err := s.validator.ReturnContext(ctx)
+	s.ioMu.Unlock()
+
+	if err != nil {
+		s.terminalPending.Store(false)
+		return err
+	}
+
+	_, err = s.awaitTerminal(ctx)
+	return err
+}
+
+// startAwaitingTerminal discards a terminal event left behind by an earlier
+// Accept/ReturnBill that gave up via ctx before claiming it, then marks
+// this call as the one run should deliver the next terminal event to.
+func (s *Session) startAwaitingTerminal() {
+	select {
+	case <-s.terminal:
+	default:
+	}
+
+	s.terminalPending.Store(true)
+}
+
+func (s *Session) awaitTerminal(ctx context.Context) (Bill, error) {
+	defer s.terminalPending.Store(false)
+
+	select {
+	case ev := <-s.terminal:
+		switch e := ev.(type) {
+		case BillStackedEvent:
+			return e.Bill, nil
+		case BillReturnedEvent:
+			return Bill{}, errors.New("bill returned")
+		case RejectedEvent:
+			return Bill{}, fmt.Errorf("bill rejected: code %X", e.Code)
+		case FailureEvent:
+			return Bill{}, fmt.Errorf("validator failure: code %X", e.Code)
+		default:
+			return Bill{}, nil
+		}
+	case <-ctx.Done():
+		return Bill{}, ctx.Err()
+	case <-s.done:
+		return Bill{}, errors.New("session closed")
+	}
+}
+
+// Close stops the poll goroutine, drains the event channel and disables all
+// bill types before returning. It is safe to call more than once.
+func (s *Session) Close() error {
+	s.cancel()
+	<-s.done
+
+	s.closeMu.Lock()
+	alreadyClosed := s.closed
+	s.closed = true
+	if !alreadyClosed {
+		close(s.events)
+	}
+	s.closeMu.Unlock()
+
+	for range s.events {
+	}
+
+	s.ioMu.Lock()
+	defer s.ioMu.Unlock()
+
+	return s.validator.EnableBillTypes(nil, nil)
+}
+
+// run's events are closed by Close, not here: closing it under closeMu is
+// what keeps publishReconnected from sending on it after Close returns.
+// Each poll gets its own ReadTimeout-bounded ctx rather than the loop's own,
+// Close-cancelled one, so a single slow or partial response can't wedge the
+// loop (and stop it from emitting events) until Close.
+func (s *Session) run(ctx context.Context, pollInterval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var prev Status
+	haveStatus := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pollCtx, cancel := context.WithTimeout(ctx, s.validator.ReadTimeout())
+		s.ioMu.Lock()
+		status, param, err := s.validator.PollContext(pollCtx)
+		s.ioMu.Unlock()
+		cancel()
+
+		if err != nil {
+			continue // transient read error, including a stalled poll; the next tick retries
+		}
+
+		if haveStatus && status == prev {
+			continue
+		}
+
+		for _, ev := range s.transition(status, param) {
+			if isTerminal(ev) && s.terminalPending.CompareAndSwap(true, false) {
+				s.terminal <- ev
+			}
+
+			select {
+			case s.events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		prev = status
+		haveStatus = true
+	}
+}
+
+func (s *Session) transition(status Status, param byte) []Event {
+	switch status {
+	case EscrowPosition:
+		bill := Bill{}
+		if int(param) < len(s.billTable) {
+			bill = s.billTable[param]
+		}
+		return []Event{BillEscrowed{Type: uint(param), Bill: bill}}
+	case BillStacked:
+		bill := Bill{}
+		if int(param) < len(s.billTable) {
+			bill = s.billTable[param]
+		}
+		return []Event{BillStackedEvent{Bill: bill}}
+	case BillReturned:
+		return []Event{BillReturnedEvent{}}
+	case Rejecting:
+		return []Event{RejectedEvent{Code: param}}
+	case GenericFailure:
+		return []Event{FailureEvent{Code: param}}
+	case ValidatorJammed, DropCassetteJammed, Cheated:
+		return []Event{FailureEvent{Code: byte(status)}}
+	case DropCassetteOutOfPosition:
+		return []Event{CassetteRemoved{}}
+	case DropCassetteFull:
+		return []Event{CassetteFull{}}
+	default:
+		return nil
+	}
+}
+
+func isTerminal(ev Event) bool {
+	switch ev.(type) {
+	case BillStackedEvent, BillReturnedEvent, RejectedEvent, FailureEvent:
+		return true
+	default:
+		return false
+	}
+}
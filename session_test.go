@@ -0,0 +1,166 @@
+package cc_validator_api_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	api "cc_validator_api"
+	"cc_validator_api/simulator"
+)
+
+func TestSessionAcceptsEscrowedBill(t *testing.T) {
+	validator, sim := simulator.NewInMemoryPair()
+
+	session := api.NewSession(validator, 10*time.Millisecond)
+	defer session.Close()
+
+	sim.InsertBill(api.Bill{Denomination: 20, CountryCode: "USD"})
+
+	want := api.Bill{Denomination: 20, CountryCode: "USD"}
+	escrowed := false
+
+	for !escrowed {
+		select {
+		case ev := <-session.Events():
+			if be, ok := ev.(api.BillEscrowed); ok {
+				if be.Bill != want {
+					t.Fatalf("BillEscrowed.Bill = %+v, want %+v", be.Bill, want)
+				}
+				escrowed = true
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for BillEscrowed")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := session.Accept(ctx); err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+}
+
+// TestSessionAcceptIgnoresStaleTerminalEvent guards against awaitTerminal
+// reporting a terminal event produced with no Accept/ReturnBill pending (a
+// device-initiated stack/return, here simulated by driving validator.Stack
+// directly instead of through the Session) as the outcome of the next,
+// unrelated Accept: see the chunk0-3 review fix.
+func TestSessionAcceptIgnoresStaleTerminalEvent(t *testing.T) {
+	validator, sim := simulator.NewInMemoryPair()
+
+	session := api.NewSession(validator, 5*time.Millisecond)
+	defer session.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	stale := api.Bill{Denomination: 5, CountryCode: "USD"}
+	sim.InsertBill(stale)
+	waitForEscrow(t, session, stale)
+
+	// Stack the escrowed bill without going through Session.Accept, so its
+	// eventual BillStackedEvent arrives with nothing pending on terminal.
+	if err := validator.Stack(); err != nil {
+		t.Fatalf("Stack: %v", err)
+	}
+	waitForStacked(t, session, stale)
+
+	want := api.Bill{Denomination: 10, CountryCode: "USD"}
+	sim.InsertBill(want)
+	waitForEscrow(t, session, want)
+
+	got, err := session.Accept(ctx)
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	if got != want {
+		t.Fatalf("Accept returned %+v, want %+v (it reported the stale stack instead of waiting for its own)", got, want)
+	}
+}
+
+func waitForEscrow(t *testing.T, session *api.Session, want api.Bill) {
+	t.Helper()
+
+	for {
+		select {
+		case ev := <-session.Events():
+			if be, ok := ev.(api.BillEscrowed); ok && be.Bill == want {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for BillEscrowed(%+v)", want)
+		}
+	}
+}
+
+func waitForStacked(t *testing.T, session *api.Session, want api.Bill) {
+	t.Helper()
+
+	for {
+		select {
+		case ev := <-session.Events():
+			if bs, ok := ev.(api.BillStackedEvent); ok && bs.Bill == want {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for BillStackedEvent(%+v)", want)
+		}
+	}
+}
+
+// delayedRecoverTransport hands back an already-broken connection on its
+// first Open, so the poll loop's first command fails and the reconnect
+// supervisor kicks in, then a real working connection (backed by a
+// simulator, so recover's Reset/Identification/GetBillTable round-trips
+// succeed) after a delay long enough for a concurrent Session.Close to run
+// first.
+type delayedRecoverTransport struct {
+	mu       sync.Mutex
+	attempts int
+}
+
+func (t *delayedRecoverTransport) Open(context.Context) (io.ReadWriteCloser, error) {
+	t.mu.Lock()
+	t.attempts++
+	n := t.attempts
+	t.mu.Unlock()
+
+	if n == 1 {
+		return brokenConn{}, nil
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	clientConn, serverConn := net.Pipe()
+	go simulator.New(serverConn).Serve()
+	return clientConn, nil
+}
+
+// TestSessionCloseDuringReconnectDoesNotPanic guards against
+// publishReconnected sending on the events channel after Close has already
+// closed it: see the chunk0-3 review fix. A pre-fix Session panics with
+// "send on closed channel" when the reconnect this test forces completes
+// after Close returns.
+func TestSessionCloseDuringReconnectDoesNotPanic(t *testing.T) {
+	validator, err := api.NewConnectionWithTransport(context.Background(), &delayedRecoverTransport{}, "test", false)
+	if err != nil {
+		t.Fatalf("NewConnectionWithTransport: %v", err)
+	}
+
+	session := api.NewSession(&validator, 5*time.Millisecond)
+
+	// Give the poll loop a moment to hit the broken connection and start a
+	// reconnect before Close races it.
+	time.Sleep(20 * time.Millisecond)
+
+	_ = session.Close()
+
+	// Let the in-flight reconnect, and the OnReconnect callback it fires,
+	// finish after Close has already returned.
+	time.Sleep(300 * time.Millisecond)
+}
@@ -0,0 +1,19 @@
+package cc_validator_api
+
+import "testing"
+
+// TestTransitionReportsCassetteRemoved guards against DropCassetteOutOfPosition
+// going to FailureEvent instead of the CassetteRemoved event the package
+// exposes for it: see the chunk0-3 review fix.
+func TestTransitionReportsCassetteRemoved(t *testing.T) {
+	s := &Session{}
+
+	events := s.transition(DropCassetteOutOfPosition, 0)
+	if len(events) != 1 {
+		t.Fatalf("transition(DropCassetteOutOfPosition) = %v, want a single event", events)
+	}
+
+	if _, ok := events[0].(CassetteRemoved); !ok {
+		t.Fatalf("transition(DropCassetteOutOfPosition) = %#v, want CassetteRemoved", events[0])
+	}
+}
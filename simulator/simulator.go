@@ -0,0 +1,285 @@
+// Package simulator implements the peripheral (bill validator) side of the
+// CCNet protocol over an io.ReadWriter. It understands the subset of the
+// protocol used by cc_validator_api.CCValidator and is meant to stand in for
+// real hardware in tests: point a CCValidator at one end of a pipe (a
+// socat-backed PTY, a net.Conn, or the in-memory pair returned by
+// NewInMemoryPair) and drive the Simulator from the other end.
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	api "cc_validator_api"
+)
+
+// Simulator walks a scripted PowerUp -> Initialize -> Idling -> Accepting ->
+// EscrowPosition -> (Stacking|Returning) -> Idling sequence in response to
+// Poll, so a test can drive a CCValidator end-to-end against a virtual bill
+// insertion via InsertBill.
+type Simulator struct {
+	rw io.ReadWriter
+
+	mu           sync.Mutex
+	status       api.Status
+	param        byte
+	insertedBill *api.Bill
+	enabled      []byte
+	security     []byte
+	billTable    []api.Bill
+	ident        api.Identification
+}
+
+// New returns a Simulator that speaks CCNet over rw, starting in the
+// PowerUp state.
+func New(rw io.ReadWriter) *Simulator {
+	return &Simulator{
+		rw:        rw,
+		status:    api.PowerUp,
+		enabled:   []byte{0, 0, 0},
+		security:  []byte{0, 0, 0},
+		billTable: defaultBillTable(),
+		ident: api.Identification{
+			PartNumber:   "CC-SIM        ",
+			SerialNumber: "0000000001 ",
+			AssetNumber:  []byte{0, 0, 0, 0, 0, 0},
+		},
+	}
+}
+
+// NewInMemoryPair returns a connected CCValidator and Simulator joined by an
+// in-memory net.Pipe, so tests can exercise the protocol without socat or a
+// real serial device. The simulator is already serving on its own goroutine
+// by the time this returns.
+func NewInMemoryPair() (*api.CCValidator, *Simulator) {
+	clientConn, serverConn := net.Pipe()
+
+	validator, err := api.NewConnectionWithTransport(context.Background(), &api.PipeTransport{Conn: clientConn}, "simulator", false)
+	if err != nil {
+		// A freshly created PipeTransport only fails to Open on its second
+		// call, which cannot happen here.
+		panic(err)
+	}
+
+	sim := New(serverConn)
+
+	go sim.Serve()
+
+	return &validator, sim
+}
+
+// InsertBill simulates a bill being inserted: the next Poll observed while
+// Idling moves the state machine to Accepting and, shortly after, to
+// EscrowPosition carrying this bill.
+func (s *Simulator) InsertBill(bill api.Bill) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.insertedBill = &bill
+}
+
+// Serve reads frames from rw and answers them until rw is closed or an
+// unrecoverable framing error occurs.
+func (s *Simulator) Serve() error {
+	for {
+		cmd, data, err := readFrame(s.rw)
+
+		if err != nil {
+			return err
+		}
+
+		resp, ok := s.handle(cmd, data)
+
+		if !ok {
+			continue
+		}
+
+		if err := writeFrame(s.rw, resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Simulator) handle(cmd byte, data []byte) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch cmd {
+	case 0x30: // Reset
+		s.status = api.PowerUp
+		s.insertedBill = nil
+		return []byte{0x00}, true
+	case 0x31: // GetStatus
+		resp := make([]byte, 0, 7)
+		resp = append(resp, s.enabled...)
+		resp = append(resp, 0x00) // reserved, skipped by CCValidator.GetStatus
+		resp = append(resp, s.security...)
+		return resp, true
+	case 0x32: // SetSecurity
+		if len(data) == 3 {
+			s.security = append([]byte{}, data...)
+		}
+		return []byte{0x00}, true
+	case 0x33: // Poll
+		return s.poll(), true
+	case 0x34: // EnableBillTypes
+		if len(data) == 6 {
+			s.enabled = append([]byte{}, data[:3]...)
+		}
+		return []byte{0x00}, true
+	case 0x35: // Stack
+		if s.status == api.EscrowPosition {
+			s.status = api.Stacking
+		}
+		return []byte{0x00}, true
+	case 0x36: // Return
+		if s.status == api.EscrowPosition {
+			s.status = api.Returning
+		}
+		return []byte{0x00}, true
+	case 0x37: // Identification
+		return s.identification(), true
+	case 0x38: // Hold
+		s.status = api.Holding
+		return []byte{0x00}, true
+	case 0x41: // GetBillTable
+		return encodeBillTable(s.billTable), true
+	case 0x51: // GetCRC32
+		return []byte{0xEF, 0xBE, 0xAD, 0xDE}, true
+	case 0x00, 0xFF: // Ack / Nack
+		// Mirrors the fire-and-forget internal Ack() helper in
+		// cc_validator_api: the real device never solicits a reply to an
+		// ACK/NAK, so neither do we.
+		return nil, false
+	default:
+		return []byte{0x30}, true // Illegal command
+	}
+}
+
+func (s *Simulator) poll() []byte {
+	switch s.status {
+	case api.PowerUp:
+		s.status = api.Initialize
+	case api.Initialize:
+		s.status = api.Idling
+	case api.Idling:
+		if s.insertedBill != nil {
+			s.status = api.Accepting
+			s.param = s.billIndex(*s.insertedBill)
+			s.insertedBill = nil
+		}
+	case api.Accepting:
+		s.status = api.EscrowPosition
+	case api.Stacking:
+		s.status = api.BillStacked
+	case api.BillStacked:
+		s.status = api.Idling
+	case api.Returning:
+		s.status = api.BillReturned
+	case api.BillReturned:
+		s.status = api.Idling
+	}
+
+	return []byte{byte(s.status), s.param}
+}
+
+// billIndex returns bill's position in s.billTable, the type/index byte
+// CCValidator.Poll reports alongside EscrowPosition and BillStacked, or 0 if
+// bill isn't in the table.
+func (s *Simulator) billIndex(bill api.Bill) byte {
+	for i, b := range s.billTable {
+		if b == bill {
+			return byte(i)
+		}
+	}
+	return 0
+}
+
+func (s *Simulator) identification() []byte {
+	buf := make([]byte, 34)
+	copy(buf[:15], s.ident.PartNumber)
+	copy(buf[16:27], s.ident.SerialNumber)
+	copy(buf[28:34], s.ident.AssetNumber)
+	return buf
+}
+
+func defaultBillTable() []api.Bill {
+	bills := make([]api.Bill, 24)
+	bills[0] = api.Bill{Denomination: 1, CountryCode: "USD"}
+	bills[1] = api.Bill{Denomination: 5, CountryCode: "USD"}
+	bills[2] = api.Bill{Denomination: 10, CountryCode: "USD"}
+	bills[3] = api.Bill{Denomination: 20, CountryCode: "USD"}
+	return bills
+}
+
+func encodeBillTable(bills []api.Bill) []byte {
+	buf := make([]byte, 24*5)
+
+	for i := 0; i < 24 && i < len(bills); i++ {
+		first, second := encodeDenomination(bills[i].Denomination)
+		buf[i*5] = first
+		copy(buf[i*5+1:i*5+4], bills[i].CountryCode)
+		buf[i*5+4] = second
+	}
+
+	return buf
+}
+
+// encodeDenomination inverts the first*10^second decoding in
+// CCValidator.GetBillTable for the handful of denominations the default
+// table uses (a single significant digit with a non-negative exponent).
+func encodeDenomination(denomination float64) (first byte, second byte) {
+	for denomination >= 256 {
+		denomination /= 10
+		second++
+	}
+	return byte(denomination), second
+}
+
+func readFrame(r io.Reader) (cmd byte, data []byte, err error) {
+	header := make([]byte, 3)
+
+	if _, err = io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	if header[0] != api.StartCode || header[1] != api.PeripheralAddress {
+		return 0, nil, fmt.Errorf("simulator: invalid frame header %X", header)
+	}
+
+	rest := make([]byte, int(header[2])-3)
+
+	if _, err = io.ReadFull(r, rest); err != nil {
+		return 0, nil, err
+	}
+
+	frame := append(header, rest...)
+	crc := binary.LittleEndian.Uint16(frame[len(frame)-2:])
+	payload := frame[:len(frame)-2]
+
+	if crc != api.GetCRC16(payload) {
+		return 0, nil, fmt.Errorf("simulator: crc mismatch")
+	}
+
+	return payload[3], payload[4:], nil
+}
+
+func writeFrame(w io.Writer, data []byte) error {
+	buf := new(bytes.Buffer)
+
+	buf.WriteByte(api.StartCode)
+	buf.WriteByte(api.PeripheralAddress)
+	buf.WriteByte(byte(3 + len(data) + 2))
+	buf.Write(data)
+
+	crc := api.GetCRC16(buf.Bytes())
+	_ = binary.Write(buf, binary.LittleEndian, crc)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
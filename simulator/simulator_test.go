@@ -0,0 +1,103 @@
+package simulator_test
+
+import (
+	"testing"
+	"time"
+
+	api "cc_validator_api"
+	"cc_validator_api/simulator"
+)
+
+func TestInMemoryPairAcceptsBill(t *testing.T) {
+	validator, sim := simulator.NewInMemoryPair()
+	defer validator.Close()
+
+	status, _, err := validator.Poll() // PowerUp -> Initialize
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if status != api.Initialize {
+		t.Fatalf("status = %X, want Initialize", status)
+	}
+
+	status, _, err = validator.Poll() // Initialize -> Idling
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if status != api.Idling {
+		t.Fatalf("status = %X, want Idling", status)
+	}
+
+	sim.InsertBill(api.Bill{Denomination: 20, CountryCode: "USD"})
+
+	status, _, err = validator.Poll() // Idling -> Accepting
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if status != api.Accepting {
+		t.Fatalf("status = %X, want Accepting", status)
+	}
+
+	status, param, err := validator.Poll() // Accepting -> EscrowPosition
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if status != api.EscrowPosition {
+		t.Fatalf("status = %X, want EscrowPosition", status)
+	}
+
+	billTable, err := validator.GetBillTable()
+	if err != nil {
+		t.Fatalf("GetBillTable: %v", err)
+	}
+	if got, want := billTable[param], (api.Bill{Denomination: 20, CountryCode: "USD"}); got != want {
+		t.Fatalf("escrowed bill = %+v, want %+v", got, want)
+	}
+
+	if err := validator.Stack(); err != nil {
+		t.Fatalf("Stack: %v", err)
+	}
+
+	status, _, err = validator.Poll() // Stacking -> BillStacked
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if status != api.BillStacked {
+		t.Fatalf("status = %X, want BillStacked", status)
+	}
+
+	status, _, err = validator.Poll() // BillStacked -> Idling
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if status != api.Idling {
+		t.Fatalf("status = %X, want Idling", status)
+	}
+}
+
+func TestInMemoryPairIdentification(t *testing.T) {
+	validator, _ := simulator.NewInMemoryPair()
+	defer validator.Close()
+
+	done := make(chan struct{})
+	var ident api.Identification
+	var err error
+
+	go func() {
+		ident, err = validator.Identification()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Identification did not return in time")
+	}
+
+	if err != nil {
+		t.Fatalf("Identification: %v", err)
+	}
+	if ident.SerialNumber == "" {
+		t.Fatal("expected a non-empty serial number")
+	}
+}
@@ -0,0 +1,308 @@
+package cc_validator_api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tarm/serial"
+)
+
+// Transport opens the connection a CCValidator speaks over. It exists so the
+// reconnect supervisor below can transparently reopen the connection after a
+// read/write error without CCValidator itself knowing how the connection is
+// established.
+type Transport interface {
+	Open(ctx context.Context) (io.ReadWriteCloser, error)
+}
+
+// SerialTransport opens a real serial port via github.com/tarm/serial. It is
+// what NewConnection uses internally.
+type SerialTransport struct {
+	Config *serial.Config
+}
+
+func (t SerialTransport) Open(ctx context.Context) (io.ReadWriteCloser, error) {
+	return serial.OpenPort(t.Config)
+}
+
+// NetTransport dials a net.Conn, so a validator exposed remotely (e.g. via
+// `socat TCP-LISTEN:...`) can be used as if it were a local serial device.
+type NetTransport struct {
+	Network string // e.g. "tcp"
+	Address string
+}
+
+func (t NetTransport) Open(ctx context.Context) (io.ReadWriteCloser, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, t.Network, t.Address)
+}
+
+// PipeTransport wraps an already-open connection, typically one half of a
+// net.Pipe handed to the in-process simulator. There is nothing to redial
+// once it has been used, so a second Open call fails; callers that need
+// real reconnect behaviour against a simulator should back it with a
+// NetTransport and `socat TCP-LISTEN:...` instead.
+type PipeTransport struct {
+	Conn io.ReadWriteCloser
+
+	mu     sync.Mutex
+	opened bool
+}
+
+func (t *PipeTransport) Open(ctx context.Context) (io.ReadWriteCloser, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.opened {
+		return nil, errors.New("pipe transport does not support reopening")
+	}
+
+	t.opened = true
+	return t.Conn, nil
+}
+
+// lastConfig records the most recent SetSecurity/EnableBillTypes arguments
+// so the reconnect supervisor can replay them after reopening the
+// connection. It is shared (via pointer) by every copy of the CCValidator it
+// belongs to.
+type lastConfig struct {
+	mu       sync.Mutex
+	security []byte
+	haveSec  bool
+	enabled  []uint
+	escrow   []uint
+	haveBill bool
+}
+
+func (c *lastConfig) setSecurity(security []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.security = append([]byte{}, security...)
+	c.haveSec = true
+}
+
+func (c *lastConfig) setEnabled(enabled, escrow []uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.enabled = append([]uint{}, enabled...)
+	c.escrow = append([]uint{}, escrow...)
+	c.haveBill = true
+}
+
+func (c *lastConfig) snapshot() (security []byte, haveSec bool, enabled, escrow []uint, haveBill bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.security, c.haveSec, c.enabled, c.escrow, c.haveBill
+}
+
+// supervisedPort is the Port CCValidator talks to when constructed via
+// NewConnection or NewConnectionWithTransport. On a read or write error it
+// closes the failed connection and, in the background, reopens it through
+// transport with exponential backoff (100ms up to 5s), replaying Reset,
+// Identification, GetBillTable, SetSecurity and EnableBillTypes to restore
+// the validator to its last known configuration. Read and Write never wait
+// on this: they return the original error immediately so a caller blocked on
+// them (including NewSession's initial GetBillTableContext and
+// Session.Close) observes it promptly instead of hanging until the
+// connection comes back.
+type supervisedPort struct {
+	transport Transport
+	validator *CCValidator
+
+	mu   sync.Mutex
+	conn io.ReadWriteCloser
+
+	// lifetime is cancelled by Close so a reconnect loop in progress gives up
+	// instead of retrying forever against a port nobody is using any more.
+	lifetime context.Context
+	shutdown context.CancelFunc
+
+	reconnectMu  sync.Mutex
+	reconnecting bool
+
+	onReconnectMu sync.Mutex
+	onReconnect   func()
+}
+
+func newSupervisedPort(ctx context.Context, transport Transport, validator *CCValidator) (*supervisedPort, error) {
+	conn, err := transport.Open(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime, shutdown := context.WithCancel(context.Background())
+
+	return &supervisedPort{transport: transport, validator: validator, conn: conn, lifetime: lifetime, shutdown: shutdown}, nil
+}
+
+func (p *supervisedPort) setOnReconnect(fn func()) {
+	p.onReconnectMu.Lock()
+	defer p.onReconnectMu.Unlock()
+
+	p.onReconnect = fn
+}
+
+func (p *supervisedPort) Read(buf []byte) (int, error) {
+	p.mu.Lock()
+	conn := p.conn
+	p.mu.Unlock()
+
+	n, err := conn.Read(buf)
+
+	if err != nil {
+		p.triggerReconnect()
+	}
+
+	return n, err
+}
+
+func (p *supervisedPort) Write(buf []byte) (int, error) {
+	p.mu.Lock()
+	conn := p.conn
+	p.mu.Unlock()
+
+	n, err := conn.Write(buf)
+
+	if err != nil {
+		p.triggerReconnect()
+	}
+
+	return n, err
+}
+
+func (p *supervisedPort) Close() error {
+	p.shutdown()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.conn.Close()
+}
+
+// triggerReconnect starts reconnect on its own goroutine, unless one is
+// already running, so Read/Write return the triggering error to their caller
+// immediately instead of blocking on recovery.
+func (p *supervisedPort) triggerReconnect() {
+	p.reconnectMu.Lock()
+	if p.reconnecting {
+		p.reconnectMu.Unlock()
+		return
+	}
+	p.reconnecting = true
+	p.reconnectMu.Unlock()
+
+	go func() {
+		p.reconnect(p.lifetime)
+
+		p.reconnectMu.Lock()
+		p.reconnecting = false
+		p.reconnectMu.Unlock()
+	}()
+}
+
+// tryOpen makes a single attempt to (re)open the connection and, on success,
+// replays the validator's last known configuration. It is used both by
+// CCValidator.Open (which should fail fast) and as the unit of work the
+// backoff loop in reconnect retries.
+func (p *supervisedPort) tryOpen(ctx context.Context) error {
+	conn, err := p.transport.Open(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.conn = conn
+	p.mu.Unlock()
+
+	return p.recover(ctx)
+}
+
+// recover re-establishes the validator's configuration against a freshly
+// (re)opened connection. It holds v.dispatch for its entire sequence, not
+// just each individual command, so an ordinary command dispatched through
+// the same CCValidator (e.g. by a Session's poll loop) can never interleave
+// its Write with one of recover's frames; see the chunk0-4 review fix. It
+// calls the unexported *Locked siblings of the *Context methods it needs
+// directly, rather than those methods themselves, since they would
+// otherwise try to re-acquire v.dispatch and deadlock against the lock held
+// here.
+func (p *supervisedPort) recover(ctx context.Context) error {
+	v := p.validator
+
+	v.dispatch.Lock()
+	defer v.dispatch.Unlock()
+
+	if err := v.resetLocked(ctx); err != nil {
+		return err
+	}
+
+	if _, err := v.identificationLocked(ctx); err != nil {
+		return err
+	}
+
+	if _, err := v.getBillTableLocked(ctx); err != nil {
+		return err
+	}
+
+	if v.cfg == nil {
+		return nil
+	}
+
+	security, haveSec, enabled, escrow, haveBill := v.cfg.snapshot()
+
+	if haveSec {
+		if err := v.setSecurityLocked(ctx, security); err != nil {
+			return err
+		}
+	}
+
+	if haveBill {
+		if err := v.enableBillTypesLocked(ctx, enabled, escrow); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *supervisedPort) reconnect(ctx context.Context) {
+	p.mu.Lock()
+	_ = p.conn.Close()
+	p.mu.Unlock()
+
+	const maxBackoff = 5 * time.Second
+	backoff := 100 * time.Millisecond
+
+	for {
+		if err := p.tryOpen(ctx); err == nil {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+
+	p.onReconnectMu.Lock()
+	fn := p.onReconnect
+	p.onReconnectMu.Unlock()
+
+	if fn != nil {
+		fn()
+	}
+}
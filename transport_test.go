@@ -0,0 +1,216 @@
+package cc_validator_api_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	api "cc_validator_api"
+	"cc_validator_api/simulator"
+)
+
+// brokenConn always fails Read and Write, simulating a connection that has
+// already dropped.
+type brokenConn struct{}
+
+func (brokenConn) Read([]byte) (int, error)  { return 0, io.ErrClosedPipe }
+func (brokenConn) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
+func (brokenConn) Close() error              { return nil }
+
+// idleConn never returns data or an error from Read, simulating a device
+// that has stopped answering; Write always succeeds.
+type idleConn struct{}
+
+func (idleConn) Read([]byte) (int, error)    { return 0, nil }
+func (idleConn) Write(p []byte) (int, error) { return len(p), nil }
+func (idleConn) Close() error                { return nil }
+
+// TestPollReturnsAgainstAnUnresponsiveDevice guards against Poll and the
+// other plain, non-Context wrapper methods hanging forever against a device
+// that never answers: see the chunk0-2 review fix. They now bound their
+// request on CCValidator.ReadTimeout instead of an unbounded
+// context.Background.
+func TestPollReturnsAgainstAnUnresponsiveDevice(t *testing.T) {
+	v := api.NewConnectionWithPort(idleConn{}, false)
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := v.Poll()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Poll against an unresponsive device returned nil, want an error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Poll against an unresponsive device never returned")
+	}
+}
+
+// onceThenFailTransport succeeds its first Open (handing back a connection
+// that is already broken) and fails every subsequent one, so the reconnect
+// supervisor's backoff loop never succeeds.
+type onceThenFailTransport struct {
+	mu       sync.Mutex
+	attempts int
+}
+
+func (t *onceThenFailTransport) Open(context.Context) (io.ReadWriteCloser, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.attempts++
+	if t.attempts == 1 {
+		return brokenConn{}, nil
+	}
+	return nil, io.ErrClosedPipe
+}
+
+// TestNewSessionReturnsWhenReconnectCannotSucceed guards against reconnect
+// blocking Read/Write (and so NewSession and Session.Close) forever when the
+// transport never reopens: see the chunk0-4 review fix.
+func TestNewSessionReturnsWhenReconnectCannotSucceed(t *testing.T) {
+	validator, err := api.NewConnectionWithTransport(context.Background(), &onceThenFailTransport{}, "test", false)
+	if err != nil {
+		t.Fatalf("NewConnectionWithTransport: %v", err)
+	}
+
+	done := make(chan *api.Session, 1)
+	go func() {
+		done <- api.NewSession(&validator, 10*time.Millisecond)
+	}()
+
+	var session *api.Session
+	select {
+	case session = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("NewSession blocked instead of returning once the transport failed to reopen")
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- session.Close()
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Session.Close blocked instead of returning once the transport failed to reopen")
+	}
+}
+
+// overlappingWriteConn flags overlap if two Write calls are ever in flight
+// on it at once, so a test can detect the exact byte-interleaving race the
+// chunk0-4 review fix closes: a brief sleep mid-Write widens the window a
+// racing second Write would need to land in.
+type overlappingWriteConn struct {
+	io.ReadWriteCloser
+
+	mu      sync.Mutex
+	writing bool
+	overlap atomic.Bool
+}
+
+func (c *overlappingWriteConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	if c.writing {
+		c.overlap.Store(true)
+	}
+	c.writing = true
+	c.mu.Unlock()
+
+	time.Sleep(2 * time.Millisecond)
+
+	c.mu.Lock()
+	c.writing = false
+	c.mu.Unlock()
+
+	return c.ReadWriteCloser.Write(p)
+}
+
+// recoverRaceTransport hands back an already-broken connection on its first
+// Open, so the first command issued against it fails and triggers a
+// reconnect, then a simulator-backed connection wrapped in
+// overlappingWriteConn on every subsequent Open.
+type recoverRaceTransport struct {
+	mu       sync.Mutex
+	attempts int
+	conn     *overlappingWriteConn
+}
+
+func (t *recoverRaceTransport) Open(context.Context) (io.ReadWriteCloser, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.attempts++
+	if t.attempts == 1 {
+		return brokenConn{}, nil
+	}
+
+	clientConn, serverConn := net.Pipe()
+	go simulator.New(serverConn).Serve()
+
+	t.conn = &overlappingWriteConn{ReadWriteCloser: clientConn}
+	return t.conn, nil
+}
+
+// TestRecoverSerializesAgainstOrdinaryCommands guards against recover's
+// Reset/Identification/GetBillTable/SetSecurity/EnableBillTypes replay
+// racing an ordinary command's Write on the same connection: see the
+// chunk0-4 review fix. It forces a reconnect, then hammers the validator
+// with concurrent GetStatusContext calls for the duration of the recovery
+// and after, and fails if any two Writes were ever observed in flight at
+// once.
+func TestRecoverSerializesAgainstOrdinaryCommands(t *testing.T) {
+	transport := &recoverRaceTransport{}
+
+	validator, err := api.NewConnectionWithTransport(context.Background(), transport, "test", false)
+	if err != nil {
+		t.Fatalf("NewConnectionWithTransport: %v", err)
+	}
+	defer validator.Close()
+
+	// This fails against the still-broken first connection and triggers the
+	// reconnect supervisor in the background.
+	_ = validator.Reset()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_, _, _ = validator.GetStatus()
+			}
+		}()
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	transport.mu.Lock()
+	conn := transport.conn
+	transport.mu.Unlock()
+
+	if conn == nil {
+		t.Fatal("reconnect never reached the simulator-backed connection")
+	}
+
+	if conn.overlap.Load() {
+		t.Fatal("observed two Write calls in flight at once: recover and an ordinary command raced the wire")
+	}
+}